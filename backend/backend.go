@@ -0,0 +1,72 @@
+// Package backend defines the storage interface used by the accord
+// service to persist handle state, and is implemented by one or more
+// storage engines (see the backend/etcd and backend/redis packages).
+package backend
+
+import (
+	"context"
+	"time"
+
+	"github.com/bsm/accord/rpc"
+	"github.com/google/uuid"
+)
+
+// HandleData is the storage representation of an acquired handle.
+type HandleData struct {
+	ID          uuid.UUID
+	Name        string
+	Namespace   string
+	ExpTime     time.Time
+	NumAcquired int
+	Metadata    map[string]string
+	// Fence is a monotonically increasing token assigned by the backend
+	// on a successful Acquire and preserved across Renew calls.
+	// Downstream systems should reject writes tagged with a fence
+	// smaller than the highest one they have already observed.
+	Fence uint64
+}
+
+// EventType identifies the kind of change a Event describes.
+type EventType int
+
+// Event types reported by Subscribe.
+const (
+	EventAcquired EventType = iota
+	EventRenewed
+	EventDone
+	EventExpired
+)
+
+// Event describes a state change for a single handle, as reported by
+// Subscribe.
+type Event struct {
+	Type      EventType
+	Namespace string
+	Name      string
+	Handle    *HandleData // nil when Type is EventExpired
+}
+
+// Backend is implemented by storage engines backing the accord service.
+type Backend interface {
+	// Acquire attempts to acquire a named handle for owner. It returns
+	// accord.ErrAcquired if the handle is currently held by someone else,
+	// or accord.ErrDone if it has already been marked done.
+	Acquire(ctx context.Context, owner, namespace, name string, expTime time.Time, metadata map[string]string) (*HandleData, error)
+	// Renew extends the TTL of a handle owned by owner, returning its
+	// (unchanged) fence token.
+	Renew(ctx context.Context, owner string, handleID uuid.UUID, expTime time.Time, metadata map[string]string) (*HandleData, error)
+	// Done marks a handle owned by owner as permanently completed.
+	Done(ctx context.Context, owner string, handleID uuid.UUID, metadata map[string]string) error
+	// List streams handles matching filter to fn. Iteration stops at the
+	// first error returned by fn.
+	List(ctx context.Context, filter *rpc.ListRequest_Filter, fn func(*HandleData) error) error
+	// Subscribe streams state-change events for handles under filter's
+	// prefix to fn, blocking until ctx is done or fn/the backend returns an
+	// error. filter.Status is not applied to events.
+	Subscribe(ctx context.Context, filter *rpc.ListRequest_Filter, fn func(*Event) error) error
+	// Ping checks connectivity to the underlying store, for gRPC health
+	// reporting.
+	Ping(ctx context.Context) error
+	// Close releases any resources held by the backend.
+	Close() error
+}
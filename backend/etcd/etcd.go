@@ -0,0 +1,434 @@
+// Package etcd implements the accord backend.Backend interface on top
+// of etcd v3, for highly available, replicated deployments that don't
+// want to run a separate SQL database.
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/bsm/accord"
+	"github.com/bsm/accord/backend"
+	"github.com/bsm/accord/rpc"
+	"github.com/google/uuid"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Options contains options for the etcd backend.
+type Options struct {
+	// Prefix namespaces all keys written by this backend, default: "accord/"
+	Prefix string
+}
+
+func (o *Options) norm() *Options {
+	var p Options
+	if o != nil {
+		p = *o
+	}
+	if p.Prefix == "" {
+		p.Prefix = "accord/"
+	}
+	return &p
+}
+
+// Backend is an etcd v3 backed implementation of backend.Backend.
+type Backend struct {
+	cli    *clientv3.Client
+	opt    *Options
+	ownCli *clientv3.Client
+}
+
+// New wraps an existing etcd client. The caller remains responsible for
+// closing cli.
+func New(cli *clientv3.Client, opt *Options) backend.Backend {
+	return &Backend{cli: cli, opt: opt.norm()}
+}
+
+// Dial creates a new etcd client and wraps it. The client is closed
+// when the backend is closed.
+func Dial(cfg clientv3.Config, opt *Options) (backend.Backend, error) {
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{cli: cli, opt: opt.norm(), ownCli: cli}, nil
+}
+
+// record is the value stored under a handle's data key.
+type record struct {
+	ID          uuid.UUID         `json:"id"`
+	Owner       string            `json:"owner"`
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	ExpTime     time.Time         `json:"exp_time"`
+	NumAcquired int               `json:"num_acquired"`
+	Metadata    map[string]string `json:"metadata"`
+	Done        bool              `json:"done"`
+	Fence       uint64            `json:"fence"`
+	// LeaseID is the etcd lease backing this handle's key. It is granted
+	// on Acquire and replaced by a freshly granted lease on every Renew;
+	// the old lease is revoked, not kept alive.
+	LeaseID int64 `json:"lease_id"`
+}
+
+func (b *Backend) dataKey(namespace, name string) string {
+	return b.opt.Prefix + "data/" + namespace + "/" + name
+}
+
+func (b *Backend) idKey(id uuid.UUID) string {
+	return b.opt.Prefix + "ids/" + id.String()
+}
+
+// Acquire implements backend.Backend.
+func (b *Backend) Acquire(ctx context.Context, owner, namespace, name string, expTime time.Time, metadata map[string]string) (*backend.HandleData, error) {
+	key := b.dataKey(namespace, name)
+
+	for {
+		gr, err := b.cli.Get(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		var rec record
+		var cmp clientv3.Cmp
+		if len(gr.Kvs) == 0 {
+			cmp = clientv3.Compare(clientv3.CreateRevision(key), "=", 0)
+		} else {
+			kv := gr.Kvs[0]
+			if err := json.Unmarshal(kv.Value, &rec); err != nil {
+				return nil, err
+			}
+			if rec.Done {
+				return nil, accord.ErrDone
+			}
+			if rec.ExpTime.After(time.Now()) {
+				return nil, accord.ErrAcquired
+			}
+			cmp = clientv3.Compare(clientv3.ModRevision(key), "=", kv.ModRevision)
+		}
+
+		rec.ID = uuid.New()
+		rec.Owner = owner
+		rec.Namespace = namespace
+		rec.Name = name
+		rec.ExpTime = expTime
+		rec.Metadata = metadata
+		rec.NumAcquired++
+		// The fence token is one past the store revision the key was read
+		// at. The CAS transaction below commits at a later revision, and
+		// any later successful acquire must read at or after that commit,
+		// so tokens for a key keep increasing even after its lease expired
+		// and deleted the record. It is stored on the record, so it never
+		// changes across a Renew and needs no follow-up write.
+		rec.Fence = uint64(gr.Header.Revision) + 1
+		rec.Done = false
+
+		lease, err := b.cli.Grant(ctx, leaseSeconds(expTime))
+		if err != nil {
+			return nil, err
+		}
+		rec.LeaseID = int64(lease.ID)
+
+		val, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		tr, err := b.cli.Txn(ctx).
+			If(cmp).
+			Then(
+				clientv3.OpPut(key, string(val), clientv3.WithLease(lease.ID)),
+				clientv3.OpPut(b.idKey(rec.ID), key, clientv3.WithLease(lease.ID)),
+			).Commit()
+		if err != nil {
+			return nil, err
+		}
+		if !tr.Succeeded {
+			continue // lost the race with another acquirer, retry
+		}
+
+		return &backend.HandleData{
+			ID:          rec.ID,
+			Name:        name,
+			Namespace:   namespace,
+			ExpTime:     rec.ExpTime,
+			NumAcquired: rec.NumAcquired,
+			Metadata:    rec.Metadata,
+			Fence:       rec.Fence,
+		}, nil
+	}
+}
+
+// Renew implements backend.Backend. It honors the caller-supplied
+// expTime on every call (matching the Redis backend), which means a
+// changed TTL needs a lease sized to it: Renew grants a fresh lease,
+// moves the key onto it within the same CAS transaction as today, and
+// revokes the old lease once that commits so it doesn't leak, same as
+// the cleanup Done already does for its own lease.
+func (b *Backend) Renew(ctx context.Context, owner string, handleID uuid.UUID, expTime time.Time, metadata map[string]string) (*backend.HandleData, error) {
+	for {
+		key, rec, modRev, err := b.lookup(ctx, owner, handleID)
+		if err != nil {
+			return nil, err
+		}
+
+		oldLeaseID := clientv3.LeaseID(rec.LeaseID)
+
+		lease, err := b.cli.Grant(ctx, leaseSeconds(expTime))
+		if err != nil {
+			return nil, err
+		}
+		rec.LeaseID = int64(lease.ID)
+		rec.ExpTime = expTime
+		rec.Metadata = metadata
+
+		val, err := json.Marshal(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		tr, err := b.cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", modRev)).
+			Then(
+				clientv3.OpPut(key, string(val), clientv3.WithLease(lease.ID)),
+				clientv3.OpPut(b.idKey(handleID), key, clientv3.WithLease(lease.ID)),
+			).Commit()
+		if err != nil {
+			return nil, err
+		}
+		if !tr.Succeeded {
+			continue // handle was concurrently modified (e.g. a racing Done), retry
+		}
+
+		// The key has moved onto the new lease; release the old one so
+		// it doesn't sit around idle until its own TTL lapses.
+		_, _ = b.cli.Revoke(ctx, oldLeaseID)
+
+		return &backend.HandleData{
+			ID:          rec.ID,
+			Name:        rec.Name,
+			Namespace:   rec.Namespace,
+			ExpTime:     rec.ExpTime,
+			NumAcquired: rec.NumAcquired,
+			Metadata:    rec.Metadata,
+			Fence:       rec.Fence,
+		}, nil
+	}
+}
+
+// Done implements backend.Backend.
+func (b *Backend) Done(ctx context.Context, owner string, handleID uuid.UUID, metadata map[string]string) error {
+	for {
+		key, rec, modRev, err := b.lookup(ctx, owner, handleID)
+		if err != nil {
+			return err
+		}
+
+		rec.Done = true
+		rec.Metadata = metadata
+
+		val, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		// Put without a lease so the done marker survives indefinitely,
+		// matching the client's long-lived local done cache.
+		tr, err := b.cli.Txn(ctx).
+			If(clientv3.Compare(clientv3.ModRevision(key), "=", modRev)).
+			Then(
+				clientv3.OpPut(key, string(val)),
+				clientv3.OpPut(b.idKey(handleID), key),
+			).Commit()
+		if err != nil {
+			return err
+		}
+		if !tr.Succeeded {
+			continue // handle was concurrently modified (e.g. a racing Renew), retry
+		}
+
+		// Put above already detached the key from its lease; revoke it
+		// too so it doesn't keep consuming an etcd lease slot until its
+		// own TTL lapses. Best-effort: the key is done either way.
+		_, _ = b.cli.Revoke(ctx, clientv3.LeaseID(rec.LeaseID))
+		return nil
+	}
+}
+
+// List implements backend.Backend.
+func (b *Backend) List(ctx context.Context, filter *rpc.ListRequest_Filter, fn func(*backend.HandleData) error) error {
+	prefix := b.opt.Prefix + "data/"
+	if filter != nil {
+		prefix += filter.Prefix
+	}
+
+	gr, err := b.cli.Get(ctx, prefix, clientv3.WithPrefix())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, kv := range gr.Kvs {
+		var rec record
+		if err := json.Unmarshal(kv.Value, &rec); err != nil {
+			return err
+		}
+
+		if filter != nil {
+			switch filter.Status {
+			case rpc.ListRequest_Filter_DONE:
+				if !rec.Done {
+					continue
+				}
+			case rpc.ListRequest_Filter_HELD:
+				if rec.Done || !rec.ExpTime.After(now) {
+					continue
+				}
+			}
+		}
+
+		if err := fn(&backend.HandleData{
+			ID:          rec.ID,
+			Name:        rec.Name,
+			Namespace:   rec.Namespace,
+			ExpTime:     rec.ExpTime,
+			NumAcquired: rec.NumAcquired,
+			Metadata:    rec.Metadata,
+			Fence:       rec.Fence,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe implements backend.Backend, using etcd's native watch.
+func (b *Backend) Subscribe(ctx context.Context, filter *rpc.ListRequest_Filter, fn func(*backend.Event) error) error {
+	prefix := b.opt.Prefix + "data/"
+	if filter != nil {
+		prefix += filter.Prefix
+	}
+
+	wc := b.cli.Watch(ctx, prefix, clientv3.WithPrefix(), clientv3.WithPrevKV())
+	for wresp := range wc {
+		if err := wresp.Err(); err != nil {
+			return err
+		}
+		for _, ev := range wresp.Events {
+			be, err := toEvent(ev)
+			if err != nil {
+				return err
+			}
+			if be == nil {
+				continue
+			}
+			if err := fn(be); err != nil {
+				return err
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+func toEvent(ev *clientv3.Event) (*backend.Event, error) {
+	if ev.Type == clientv3.EventTypeDelete {
+		// The key was removed by its lease expiring rather than an
+		// explicit Done call.
+		if ev.PrevKv == nil {
+			return nil, nil
+		}
+		var prev record
+		if err := json.Unmarshal(ev.PrevKv.Value, &prev); err != nil {
+			return nil, err
+		}
+		return &backend.Event{Type: backend.EventExpired, Namespace: prev.Namespace, Name: prev.Name}, nil
+	}
+
+	var rec record
+	if err := json.Unmarshal(ev.Kv.Value, &rec); err != nil {
+		return nil, err
+	}
+
+	typ := backend.EventAcquired
+	if rec.Done {
+		typ = backend.EventDone
+	} else if ev.PrevKv != nil {
+		var prev record
+		if err := json.Unmarshal(ev.PrevKv.Value, &prev); err == nil && prev.ID == rec.ID {
+			typ = backend.EventRenewed
+		}
+	}
+
+	return &backend.Event{
+		Type:      typ,
+		Namespace: rec.Namespace,
+		Name:      rec.Name,
+		Handle: &backend.HandleData{
+			ID:          rec.ID,
+			Name:        rec.Name,
+			Namespace:   rec.Namespace,
+			ExpTime:     rec.ExpTime,
+			NumAcquired: rec.NumAcquired,
+			Metadata:    rec.Metadata,
+			Fence:       rec.Fence,
+		},
+	}, nil
+}
+
+// Ping implements backend.Backend.
+func (b *Backend) Ping(ctx context.Context) error {
+	_, err := b.cli.Get(ctx, b.opt.Prefix, clientv3.WithLimit(1), clientv3.WithCountOnly())
+	return err
+}
+
+// Close implements backend.Backend.
+func (b *Backend) Close() error {
+	if b.ownCli != nil {
+		return b.ownCli.Close()
+	}
+	return nil
+}
+
+// lookup resolves a handle by ID, verifying ownership. It also returns
+// the data key's ModRevision, so callers can CAS their follow-up write
+// against the exact version they read.
+func (b *Backend) lookup(ctx context.Context, owner string, handleID uuid.UUID) (string, *record, int64, error) {
+	gr, err := b.cli.Get(ctx, b.idKey(handleID))
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if len(gr.Kvs) == 0 {
+		return "", nil, 0, status.Error(codes.NotFound, "handle not found")
+	}
+	key := string(gr.Kvs[0].Value)
+
+	dr, err := b.cli.Get(ctx, key)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if len(dr.Kvs) == 0 {
+		return "", nil, 0, status.Error(codes.NotFound, "handle not found")
+	}
+
+	var rec record
+	if err := json.Unmarshal(dr.Kvs[0].Value, &rec); err != nil {
+		return "", nil, 0, err
+	}
+	if rec.ID != handleID || rec.Owner != owner {
+		return "", nil, 0, status.Error(codes.NotFound, "handle not found")
+	}
+	if rec.Done {
+		return "", nil, 0, accord.ErrDone
+	}
+	return key, &rec, dr.Kvs[0].ModRevision, nil
+}
+
+func leaseSeconds(expTime time.Time) int64 {
+	if d := (time.Until(expTime) + time.Second - 1) / time.Second; d > 1 {
+		return int64(d)
+	}
+	return 1
+}
@@ -0,0 +1,148 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsm/accord"
+	"github.com/bsm/accord/backend"
+	"github.com/google/uuid"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/tests/v3/integration"
+)
+
+func TestLeaseSeconds(t *testing.T) {
+	if got := leaseSeconds(time.Now().Add(5 * time.Second)); got != 5 {
+		t.Fatalf("leaseSeconds(+5s) = %d, want 5", got)
+	}
+	if got := leaseSeconds(time.Now().Add(500 * time.Millisecond)); got != 1 {
+		t.Fatalf("leaseSeconds(+500ms) = %d, want the 1s floor", got)
+	}
+	if got := leaseSeconds(time.Now().Add(-time.Minute)); got != 1 {
+		t.Fatalf("leaseSeconds(past) = %d, want the 1s floor", got)
+	}
+}
+
+func toEventRecord(t *testing.T, rec record) []byte {
+	t.Helper()
+	val, err := json.Marshal(rec)
+	if err != nil {
+		t.Fatalf("marshal record: %v", err)
+	}
+	return val
+}
+
+func TestToEventAcquiredVsRenewed(t *testing.T) {
+	id := uuid.New()
+	rec := record{ID: id, Namespace: "ns", Name: "res", Fence: 1}
+	val := toEventRecord(t, rec)
+
+	acquired, err := toEvent(&clientv3.Event{Kv: &mvccpb.KeyValue{Value: val}})
+	if err != nil {
+		t.Fatalf("toEvent() error = %v", err)
+	}
+	if acquired.Type != backend.EventAcquired {
+		t.Fatalf("Type = %v, want EventAcquired for a first write", acquired.Type)
+	}
+
+	renewed, err := toEvent(&clientv3.Event{
+		Kv:     &mvccpb.KeyValue{Value: val},
+		PrevKv: &mvccpb.KeyValue{Value: val},
+	})
+	if err != nil {
+		t.Fatalf("toEvent() error = %v", err)
+	}
+	if renewed.Type != backend.EventRenewed {
+		t.Fatalf("Type = %v, want EventRenewed when PrevKv has the same handle ID", renewed.Type)
+	}
+}
+
+func TestToEventDone(t *testing.T) {
+	rec := record{ID: uuid.New(), Namespace: "ns", Name: "res", Done: true}
+	val := toEventRecord(t, rec)
+
+	ev, err := toEvent(&clientv3.Event{
+		Kv:     &mvccpb.KeyValue{Value: val},
+		PrevKv: &mvccpb.KeyValue{Value: val},
+	})
+	if err != nil {
+		t.Fatalf("toEvent() error = %v", err)
+	}
+	if ev.Type != backend.EventDone {
+		t.Fatalf("Type = %v, want EventDone", ev.Type)
+	}
+}
+
+func TestToEventExpired(t *testing.T) {
+	rec := record{ID: uuid.New(), Namespace: "ns", Name: "res"}
+	val := toEventRecord(t, rec)
+
+	ev, err := toEvent(&clientv3.Event{
+		Type:   clientv3.EventTypeDelete,
+		PrevKv: &mvccpb.KeyValue{Value: val},
+	})
+	if err != nil {
+		t.Fatalf("toEvent() error = %v", err)
+	}
+	if ev.Type != backend.EventExpired || ev.Namespace != "ns" || ev.Name != "res" {
+		t.Fatalf("toEvent() = %+v, want an EventExpired for ns/res", ev)
+	}
+
+	// A delete with no PrevKv (e.g. a compaction artifact) carries no
+	// useful information and should be dropped rather than reported.
+	none, err := toEvent(&clientv3.Event{Type: clientv3.EventTypeDelete})
+	if err != nil {
+		t.Fatalf("toEvent() error = %v", err)
+	}
+	if none != nil {
+		t.Fatalf("toEvent() = %+v, want nil for a delete with no PrevKv", none)
+	}
+}
+
+// TestBackendRenewRetriesUnderConcurrency races several Renew calls
+// against the same handle on a real etcd cluster. Each call's CAS loop
+// should silently retry past the others' commits rather than surfacing
+// a conflict error, and a Done issued after them should stick.
+func TestBackendRenewRetriesUnderConcurrency(t *testing.T) {
+	clus := integration.NewClusterV3(t, &integration.ClusterConfig{Size: 1})
+	defer clus.Terminate(t)
+
+	cli := clus.RandClient()
+	b := New(cli, nil)
+	ctx := context.Background()
+
+	data, err := b.Acquire(ctx, "owner", "ns", "res", time.Now().Add(time.Minute), nil)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	wg.Add(len(errs))
+	for i := range errs {
+		i := i
+		go func() {
+			defer wg.Done()
+			_, errs[i] = b.Renew(ctx, "owner", data.ID, time.Now().Add(time.Minute), map[string]string{"i": fmt.Sprint(i)})
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Renew() #%d error = %v, want the CAS retry loop to absorb concurrent writers", i, err)
+		}
+	}
+
+	if err := b.Done(ctx, "owner", data.ID, nil); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	if _, err := b.Renew(ctx, "owner", data.ID, time.Now().Add(time.Minute), nil); err != accord.ErrDone {
+		t.Fatalf("Renew() after Done error = %v, want accord.ErrDone", err)
+	}
+}
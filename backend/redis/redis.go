@@ -0,0 +1,456 @@
+// Package redis implements the accord backend.Backend interface on top
+// of Redis, using hashes and a per-namespace sorted set index so that
+// Acquire remains a single atomic round-trip.
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsm/accord"
+	"github.com/bsm/accord/backend"
+	"github.com/bsm/accord/rpc"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RedisOptions contains options for the Redis backed backend.
+type RedisOptions struct {
+	// Client is the client used to talk to Redis. It accepts any
+	// redis.UniversalClient, so callers may plug in a Cluster or
+	// Sentinel-managed deployment. The backend never closes it.
+	Client redis.UniversalClient
+	// Prefix namespaces all keys written by this backend, default: "accord"
+	Prefix string
+}
+
+func (o *RedisOptions) norm() *RedisOptions {
+	var p RedisOptions
+	if o != nil {
+		p = *o
+	}
+	if p.Prefix == "" {
+		p.Prefix = "accord"
+	}
+	return &p
+}
+
+// Backend is a Redis backed implementation of backend.Backend.
+type Backend struct {
+	cli redis.UniversalClient
+	opt *RedisOptions
+}
+
+// New initializes a new Redis backed backend.
+func New(opt *RedisOptions) backend.Backend {
+	opt = opt.norm()
+	return &Backend{cli: opt.Client, opt: opt}
+}
+
+func (b *Backend) hashKey(namespace, name string) string {
+	return b.opt.Prefix + ":{" + namespace + "}:" + name
+}
+
+func (b *Backend) indexKey(namespace string) string {
+	return b.opt.Prefix + ":{" + namespace + "}:__index__"
+}
+
+func (b *Backend) idKey(id uuid.UUID) string {
+	return b.opt.Prefix + ":id:" + id.String()
+}
+
+func (b *Backend) fenceKey(namespace string) string {
+	return b.opt.Prefix + ":{" + namespace + "}:__fence__"
+}
+
+// acquireScript atomically checks the current state of a handle and,
+// if free, writes the new owner/expiry/metadata in one round-trip. The
+// fence token is taken from a per-namespace counter so it keeps
+// increasing across every acquire in the namespace.
+//
+// The id pointer key is deliberately left out of this script: it carries
+// no {namespace} hash tag (Renew/Done start with only a UUID, before
+// they know the namespace, so it can't be tagged), and co-locating an
+// untagged key with the namespace-tagged keys below in one EVAL would
+// make every call fail against a redis.ClusterClient with a CROSSSLOT
+// error. It is written separately, best-effort, by the caller.
+// KEYS: hash key, index key, fence counter key.
+// ARGV: new id, owner, exp millis, metadata JSON, now millis, name.
+var acquireScript = redis.NewScript(`
+local done = redis.call('HGET', KEYS[1], 'done')
+if done == '1' then
+	return 'DONE'
+end
+
+local exp = tonumber(redis.call('HGET', KEYS[1], 'exp_time'))
+if exp and exp > tonumber(ARGV[5]) then
+	return 'HELD'
+end
+
+local num = tonumber(redis.call('HGET', KEYS[1], 'num_acquired') or '0') + 1
+local fence = redis.call('INCR', KEYS[3])
+redis.call('HSET', KEYS[1], 'id', ARGV[1], 'owner', ARGV[2], 'exp_time', ARGV[3], 'num_acquired', num, 'metadata', ARGV[4], 'done', '0', 'fence', fence)
+redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[6])
+return num .. ':' .. fence
+`)
+
+// Acquire implements backend.Backend.
+func (b *Backend) Acquire(ctx context.Context, owner, namespace, name string, expTime time.Time, metadata map[string]string) (*backend.HandleData, error) {
+	id := uuid.New()
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	expMillis := expTime.UnixNano() / int64(time.Millisecond)
+	nowMillis := time.Now().UnixNano() / int64(time.Millisecond)
+
+	res, err := acquireScript.Run(ctx, b.cli,
+		[]string{b.hashKey(namespace, name), b.indexKey(namespace), b.fenceKey(namespace)},
+		id.String(), owner, expMillis, string(meta), nowMillis, name,
+	).Text()
+	if err != nil {
+		return nil, err
+	}
+
+	switch res {
+	case "DONE":
+		return nil, accord.ErrDone
+	case "HELD":
+		return nil, accord.ErrAcquired
+	}
+
+	num, fence, err := splitNumFence(res)
+	if err != nil {
+		return nil, err
+	}
+
+	pointer := namespace + "\x00" + name
+	if err := b.cli.Set(ctx, b.idKey(id), pointer, 0).Err(); err != nil {
+		return nil, err
+	}
+	if err := b.cli.PExpireAt(ctx, b.idKey(id), expTime).Err(); err != nil {
+		return nil, err
+	}
+
+	return &backend.HandleData{
+		ID:          id,
+		Name:        name,
+		Namespace:   namespace,
+		ExpTime:     expTime,
+		NumAcquired: num,
+		Metadata:    metadata,
+		Fence:       fence,
+	}, nil
+}
+
+func splitNumFence(res string) (num int, fence uint64, err error) {
+	parts := strings.SplitN(res, ":", 2)
+	if len(parts) != 2 {
+		return 0, 0, status.Error(codes.Internal, "malformed acquire result")
+	}
+	if num, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	if fence, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+		return 0, 0, err
+	}
+	return num, fence, nil
+}
+
+// renewScript extends the TTL of a handle already owned by owner. Like
+// acquireScript, it leaves the id pointer key out: it isn't tagged with
+// {namespace}, so refreshing it in the same EVAL as the namespace-tagged
+// keys below would break Cluster support. Renew refreshes it separately.
+// KEYS: hash key, index key.
+// ARGV: handle id, owner, exp millis, metadata JSON, name.
+var renewScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'id') ~= ARGV[1] or redis.call('HGET', KEYS[1], 'owner') ~= ARGV[2] then
+	return 'NOTFOUND'
+end
+if redis.call('HGET', KEYS[1], 'done') == '1' then
+	return 'DONE'
+end
+
+redis.call('HSET', KEYS[1], 'exp_time', ARGV[3], 'metadata', ARGV[4])
+redis.call('PEXPIREAT', KEYS[1], ARGV[3])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[5])
+return redis.call('HGET', KEYS[1], 'fence')
+`)
+
+// Renew implements backend.Backend.
+func (b *Backend) Renew(ctx context.Context, owner string, handleID uuid.UUID, expTime time.Time, metadata map[string]string) (*backend.HandleData, error) {
+	namespace, name, err := b.resolve(ctx, handleID)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	expMillis := expTime.UnixNano() / int64(time.Millisecond)
+
+	res, err := renewScript.Run(ctx, b.cli,
+		[]string{b.hashKey(namespace, name), b.indexKey(namespace)},
+		handleID.String(), owner, expMillis, string(meta), name,
+	).Text()
+	if err != nil {
+		return nil, err
+	}
+
+	switch res {
+	case "NOTFOUND":
+		return nil, status.Error(codes.NotFound, "handle not found")
+	case "DONE":
+		return nil, accord.ErrDone
+	}
+
+	fence, err := strconv.ParseUint(res, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.cli.PExpireAt(ctx, b.idKey(handleID), expTime).Err(); err != nil {
+		return nil, err
+	}
+
+	return &backend.HandleData{
+		ID:        handleID,
+		Name:      name,
+		Namespace: namespace,
+		ExpTime:   expTime,
+		Metadata:  metadata,
+		Fence:     fence,
+	}, nil
+}
+
+// doneScript marks a handle as permanently done, dropping its TTL so it
+// survives indefinitely for fetchDone-style client caches. The id
+// pointer key is, again, persisted separately rather than inline here,
+// since it isn't tagged with {namespace} and can't share an EVAL with
+// the namespace-tagged keys below under Cluster.
+// KEYS: hash key, index key.
+// ARGV: handle id, owner, metadata JSON, name.
+var doneScript = redis.NewScript(`
+if redis.call('HGET', KEYS[1], 'id') ~= ARGV[1] or redis.call('HGET', KEYS[1], 'owner') ~= ARGV[2] then
+	return 'NOTFOUND'
+end
+
+redis.call('HSET', KEYS[1], 'done', '1', 'metadata', ARGV[3])
+redis.call('PERSIST', KEYS[1])
+redis.call('ZADD', KEYS[2], -1, ARGV[4])
+return 'OK'
+`)
+
+// Done implements backend.Backend.
+func (b *Backend) Done(ctx context.Context, owner string, handleID uuid.UUID, metadata map[string]string) error {
+	namespace, name, err := b.resolve(ctx, handleID)
+	if err != nil {
+		return err
+	}
+
+	meta, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+
+	res, err := doneScript.Run(ctx, b.cli,
+		[]string{b.hashKey(namespace, name), b.indexKey(namespace)},
+		handleID.String(), owner, string(meta), name,
+	).Text()
+	if err != nil {
+		return err
+	}
+	if res == "NOTFOUND" {
+		return status.Error(codes.NotFound, "handle not found")
+	}
+
+	return b.cli.Persist(ctx, b.idKey(handleID)).Err()
+}
+
+// List implements backend.Backend.
+func (b *Backend) List(ctx context.Context, filter *rpc.ListRequest_Filter, fn func(*backend.HandleData) error) error {
+	var namespace string
+	fstatus := rpc.ListRequest_Filter_ANY
+	if filter != nil {
+		namespace = filter.Prefix
+		fstatus = filter.Status
+	}
+
+	var names []string
+	var err error
+	switch fstatus {
+	case rpc.ListRequest_Filter_DONE:
+		names, err = b.cli.ZRangeByScore(ctx, b.indexKey(namespace), &redis.ZRangeBy{Min: "-1", Max: "-1"}).Result()
+	case rpc.ListRequest_Filter_HELD:
+		now := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+		names, err = b.cli.ZRangeByScore(ctx, b.indexKey(namespace), &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	default:
+		names, err = b.cli.ZRange(ctx, b.indexKey(namespace), 0, -1).Result()
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		raw, err := b.cli.HGetAll(ctx, b.hashKey(namespace, name)).Result()
+		if err != nil {
+			return err
+		}
+		if len(raw) == 0 {
+			// The hash expired without the index entry being swept; do
+			// so now rather than surfacing a stale result.
+			b.cli.ZRem(ctx, b.indexKey(namespace), name)
+			continue
+		}
+
+		data, err := decodeHash(namespace, name, raw)
+		if err != nil {
+			return err
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Subscribe implements backend.Backend using Redis keyspace notifications.
+// The server must have notify-keyspace-events set to include keyevent
+// notifications for hash commands and expirations (e.g. "Ehx").
+func (b *Backend) Subscribe(ctx context.Context, filter *rpc.ListRequest_Filter, fn func(*backend.Event) error) error {
+	var namespace string
+	if filter != nil {
+		namespace = filter.Prefix
+	}
+	keyPrefix := b.opt.Prefix + ":{" + namespace + "}:"
+
+	pubsub := b.cli.PSubscribe(ctx, "__keyevent@*__:hset", "__keyevent@*__:expired")
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msg, ok := <-ch:
+			if !ok {
+				return nil
+			}
+
+			key := msg.Payload
+			if !strings.HasPrefix(key, keyPrefix) || strings.HasSuffix(key, "__index__") {
+				continue
+			}
+			name := strings.TrimPrefix(key, keyPrefix)
+
+			if strings.HasSuffix(msg.Channel, ":expired") {
+				if err := fn(&backend.Event{Type: backend.EventExpired, Namespace: namespace, Name: name}); err != nil {
+					return err
+				}
+				continue
+			}
+
+			raw, err := b.cli.HGetAll(ctx, key).Result()
+			if err != nil {
+				return err
+			}
+			if len(raw) == 0 {
+				continue
+			}
+
+			data, err := decodeHash(namespace, name, raw)
+			if err != nil {
+				return err
+			}
+
+			typ := backend.EventAcquired
+			if raw["done"] == "1" {
+				typ = backend.EventDone
+			} else if data.NumAcquired > 1 {
+				// Best-effort: keyspace notifications don't distinguish
+				// the first HSET of an acquire from a subsequent renew,
+				// so treat repeat acquisitions as renewals.
+				typ = backend.EventRenewed
+			}
+
+			if err := fn(&backend.Event{Type: typ, Namespace: namespace, Name: name, Handle: data}); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Ping implements backend.Backend.
+func (b *Backend) Ping(ctx context.Context) error {
+	return b.cli.Ping(ctx).Err()
+}
+
+// Close implements backend.Backend. The wrapped client is owned by the
+// caller and is left open.
+func (b *Backend) Close() error {
+	return nil
+}
+
+func (b *Backend) resolve(ctx context.Context, handleID uuid.UUID) (namespace, name string, err error) {
+	val, err := b.cli.Get(ctx, b.idKey(handleID)).Result()
+	if err == redis.Nil {
+		return "", "", status.Error(codes.NotFound, "handle not found")
+	} else if err != nil {
+		return "", "", err
+	}
+
+	parts := strings.SplitN(val, "\x00", 2)
+	if len(parts) != 2 {
+		return "", "", status.Error(codes.NotFound, "handle not found")
+	}
+	return parts[0], parts[1], nil
+}
+
+func decodeHash(namespace, name string, raw map[string]string) (*backend.HandleData, error) {
+	id, err := uuid.Parse(raw["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	expMillis, err := strconv.ParseInt(raw["exp_time"], 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	numAcquired, err := strconv.Atoi(raw["num_acquired"])
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]string
+	if m := raw["metadata"]; m != "" {
+		if err := json.Unmarshal([]byte(m), &metadata); err != nil {
+			return nil, err
+		}
+	}
+
+	var fence uint64
+	if f := raw["fence"]; f != "" {
+		if fence, err = strconv.ParseUint(f, 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	return &backend.HandleData{
+		ID:          id,
+		Name:        name,
+		Namespace:   namespace,
+		ExpTime:     time.Unix(0, expMillis*int64(time.Millisecond)),
+		NumAcquired: numAcquired,
+		Metadata:    metadata,
+		Fence:       fence,
+	}, nil
+}
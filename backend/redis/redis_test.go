@@ -0,0 +1,128 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsm/accord"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestSplitNumFence(t *testing.T) {
+	num, fence, err := splitNumFence("3:7")
+	if err != nil {
+		t.Fatalf("splitNumFence() error = %v", err)
+	}
+	if num != 3 || fence != 7 {
+		t.Fatalf("splitNumFence() = (%d, %d), want (3, 7)", num, fence)
+	}
+
+	if _, _, err := splitNumFence("malformed"); err == nil {
+		t.Fatal("splitNumFence(\"malformed\") error = nil, want an error")
+	}
+}
+
+func TestDecodeHash(t *testing.T) {
+	expTime := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+	raw := map[string]string{
+		"id":           "b3b4d7d2-7e2d-4b8e-9f0a-0f2e9a6f9b3e",
+		"exp_time":     fmt.Sprint(expTime.UnixNano() / int64(time.Millisecond)),
+		"num_acquired": "2",
+		"metadata":     `{"k":"v"}`,
+		"fence":        "9",
+	}
+
+	data, err := decodeHash("ns", "res", raw)
+	if err != nil {
+		t.Fatalf("decodeHash() error = %v", err)
+	}
+	if data.Namespace != "ns" || data.Name != "res" {
+		t.Fatalf("decodeHash() namespace/name = %s/%s, want ns/res", data.Namespace, data.Name)
+	}
+	if data.NumAcquired != 2 || data.Fence != 9 {
+		t.Fatalf("decodeHash() = %+v, want NumAcquired=2, Fence=9", data)
+	}
+	if !data.ExpTime.Equal(expTime) {
+		t.Fatalf("decodeHash() ExpTime = %v, want %v", data.ExpTime, expTime)
+	}
+	if data.Metadata["k"] != "v" {
+		t.Fatalf("decodeHash() Metadata = %+v, want {k: v}", data.Metadata)
+	}
+}
+
+func newTestBackend(t *testing.T) *Backend {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	cli := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = cli.Close() })
+	return New(&RedisOptions{Client: cli}).(*Backend)
+}
+
+func TestBackendAcquireRenewDone(t *testing.T) {
+	b := newTestBackend(t)
+	ctx := context.Background()
+
+	data, err := b.Acquire(ctx, "owner", "ns", "res", time.Now().Add(time.Minute), nil)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	if data.Fence != 1 {
+		t.Fatalf("Acquire() Fence = %d, want 1 on first acquire", data.Fence)
+	}
+
+	if _, err := b.Acquire(ctx, "other", "ns", "res", time.Now().Add(time.Minute), nil); err != accord.ErrAcquired {
+		t.Fatalf("Acquire() by a second owner error = %v, want accord.ErrAcquired", err)
+	}
+
+	renewed, err := b.Renew(ctx, "owner", data.ID, time.Now().Add(2*time.Minute), map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatalf("Renew() error = %v", err)
+	}
+	if renewed.Fence != data.Fence {
+		t.Fatalf("Renew() Fence = %d, want unchanged %d", renewed.Fence, data.Fence)
+	}
+
+	if err := b.Done(ctx, "owner", data.ID, nil); err != nil {
+		t.Fatalf("Done() error = %v", err)
+	}
+	if _, err := b.Acquire(ctx, "owner", "ns", "res", time.Now().Add(time.Minute), nil); err != accord.ErrDone {
+		t.Fatalf("Acquire() after Done error = %v, want accord.ErrDone", err)
+	}
+}
+
+// TestBackendAcquireConcurrentIsAtomic races several Acquire calls for
+// the same name: acquireScript's single EVAL round-trip should let
+// exactly one of them win, proving the check-and-set stays atomic
+// without any client-side coordination.
+func TestBackendAcquireConcurrentIsAtomic(t *testing.T) {
+	b := newTestBackend(t)
+	ctx := context.Background()
+
+	const n = 10
+	var wg sync.WaitGroup
+	oks := make([]bool, n)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			_, err := b.Acquire(ctx, fmt.Sprintf("owner-%d", i), "ns", "res", time.Now().Add(time.Minute), nil)
+			oks[i] = err == nil
+		}()
+	}
+	wg.Wait()
+
+	won := 0
+	for _, ok := range oks {
+		if ok {
+			won++
+		}
+	}
+	if won != 1 {
+		t.Fatalf("winners = %d, want exactly 1 out of %d concurrent Acquire calls", won, n)
+	}
+}
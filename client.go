@@ -55,15 +55,20 @@ func (o *ClientOptions) norm() *ClientOptions {
 type Client interface {
 	// Acquire acquires a named resource handle.
 	Acquire(ctx context.Context, name string, meta map[string]string) (*Handle, error)
+	// Watch streams handle state changes matching filter until ctx is
+	// done or the returned channel is drained and closed.
+	Watch(ctx context.Context, filter *WatchFilter) (<-chan *Event, error)
 	// Close closes the connection.
 	Close() error
 }
 
 type client struct {
-	rpc   proto.V1Client
-	opt   *ClientOptions
-	cache cache.Cache
-	ownCC *grpc.ClientConn
+	rpc     proto.V1Client
+	opt     *ClientOptions
+	cache   cache.Cache
+	ownCC   *grpc.ClientConn
+	cancel  context.CancelFunc
+	closeFn func() error // closes any connections owned by rpc, e.g. a multiRPC
 }
 
 // RPCClient inits a new client.
@@ -74,15 +79,19 @@ func RPCClient(ctx context.Context, rpc proto.V1Client, opt *ClientOptions) (Cli
 		return nil, err
 	}
 
+	watchCtx, cancel := context.WithCancel(context.Background())
 	client := &client{
-		rpc:   rpc,
-		opt:   opt,
-		cache: cache,
+		rpc:    rpc,
+		opt:    opt,
+		cache:  cache,
+		cancel: cancel,
 	}
 	if err := client.fetchDone(ctx); err != nil {
+		cancel()
 		_ = cache.Close()
 		return nil, err
 	}
+	go client.watchDone(watchCtx)
 	return client, nil
 }
 
@@ -139,11 +148,15 @@ func (c *client) Acquire(ctx context.Context, name string, meta map[string]strin
 	}
 
 	handleID := uuid.Must(uuid.FromBytes(res.Handle.Id))
-	return newHandle(handleID, c.rpc, res.Handle.Metadata, c.opt), nil
+	return newHandle(handleID, c.rpc, res.Handle.Metadata, res.Handle.Fence, c.opt), nil
 }
 
 // Close implements Client interface.
 func (c *client) Close() error {
+	if c.cancel != nil {
+		c.cancel()
+	}
+
 	var err error
 	if c.cache != nil {
 		if e2 := c.cache.Close(); e2 != nil {
@@ -155,6 +168,11 @@ func (c *client) Close() error {
 			err = e2
 		}
 	}
+	if c.closeFn != nil {
+		if e2 := c.closeFn(); e2 != nil {
+			err = e2
+		}
+	}
 	return err
 }
 
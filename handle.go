@@ -0,0 +1,73 @@
+package accord
+
+import (
+	"context"
+
+	"github.com/bsm/accord/internal/proto"
+	"github.com/google/uuid"
+)
+
+// Handle represents a resource handle acquired via Client.Acquire. It is
+// used to renew the underlying lease or mark the work it guards as done.
+type Handle struct {
+	id       uuid.UUID
+	rpc      proto.V1Client
+	metadata map[string]string
+	fence    uint64
+	opt      *ClientOptions
+}
+
+func newHandle(id uuid.UUID, rpc proto.V1Client, metadata map[string]string, fence uint64, opt *ClientOptions) *Handle {
+	return &Handle{id: id, rpc: rpc, metadata: metadata, fence: fence, opt: opt}
+}
+
+// ID returns the handle's unique identifier.
+func (h *Handle) ID() uuid.UUID {
+	return h.id
+}
+
+// Metadata returns the metadata attached to the handle as of the last
+// Acquire or Renew call.
+func (h *Handle) Metadata() map[string]string {
+	return h.metadata
+}
+
+// Fence returns the handle's fence token: a value that increases every
+// time the underlying resource changes owner, but stays unchanged across
+// Renew calls. Downstream systems should reject writes carrying a fence
+// token smaller than the highest one they have already observed, to
+// guard against a stale owner acting after it has lost the handle.
+func (h *Handle) Fence() uint64 {
+	return h.fence
+}
+
+// Renew extends the handle's TTL and updates its metadata.
+func (h *Handle) Renew(ctx context.Context, meta map[string]string) error {
+	res, err := h.rpc.Renew(ctx, &proto.RenewRequest{
+		Owner:    h.opt.Owner,
+		HandleId: h.id[:],
+		Ttl:      h.opt.ttlSeconds(),
+		Metadata: meta,
+	})
+	if err != nil {
+		return err
+	}
+
+	h.metadata = meta
+	h.fence = res.Fence
+	return nil
+}
+
+// Done marks the handle as permanently completed.
+func (h *Handle) Done(ctx context.Context, meta map[string]string) error {
+	if _, err := h.rpc.Done(ctx, &proto.DoneRequest{
+		Owner:    h.opt.Owner,
+		HandleId: h.id[:],
+		Metadata: meta,
+	}); err != nil {
+		return err
+	}
+
+	h.metadata = meta
+	return nil
+}
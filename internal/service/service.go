@@ -9,16 +9,59 @@ import (
 	"github.com/bsm/accord/rpc"
 	"github.com/google/uuid"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/status"
 )
 
+// healthCheckInterval is how often the backend is pinged to update the
+// gRPC health server's serving status.
+const healthCheckInterval = 5 * time.Second
+
 type service struct {
-	b backend.Backend
+	b      backend.Backend
+	health *health.Server
+}
+
+// New initalizes a new service, along with a gRPC health server that
+// tracks the backend's connectivity. Callers should register both the
+// returned rpc.V1Server and the health server on the same grpc.Server,
+// and call the returned stop func when tearing the service down to
+// release the background health-polling goroutine.
+func New(b backend.Backend) (rpc.V1Server, *health.Server, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	hs := health.NewServer()
+	s := &service{b: b, health: hs}
+	go s.watchHealth(ctx)
+	return s, hs, cancel
+}
+
+// watchHealth periodically pings the backend and updates the health
+// server's serving status accordingly, reporting NOT_SERVING when the
+// backend is unreachable.
+func (s *service) watchHealth(ctx context.Context) {
+	t := time.NewTicker(healthCheckInterval)
+	defer t.Stop()
+
+	for {
+		s.checkHealth(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
 }
 
-// New initalizes a new service
-func New(b backend.Backend) rpc.V1Server {
-	return &service{b: b}
+func (s *service) checkHealth(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, healthCheckInterval/2)
+	defer cancel()
+
+	st := healthpb.HealthCheckResponse_SERVING
+	if err := s.b.Ping(pingCtx); err != nil {
+		st = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.health.SetServingStatus("", st)
 }
 
 // Acquire implements rpc.V1Server.
@@ -56,10 +99,11 @@ func (s *service) Renew(ctx context.Context, req *rpc.RenewRequest) (*rpc.RenewR
 		return nil, status.Error(codes.InvalidArgument, "invalid handle ID")
 	}
 
-	if err := s.b.Renew(ctx, req.Owner, handleID, expTime(req.Ttl), req.Metadata); err != nil {
+	data, err := s.b.Renew(ctx, req.Owner, handleID, expTime(req.Ttl), req.Metadata)
+	if err != nil {
 		return nil, err
 	}
-	return &rpc.RenewResponse{}, nil
+	return &rpc.RenewResponse{Fence: data.Fence}, nil
 }
 
 // Done implements rpc.V1Server.
@@ -86,6 +130,40 @@ func (s *service) List(req *rpc.ListRequest, srv rpc.V1_ListServer) error {
 	})
 }
 
+// Watch implements rpc.V1Server.
+func (s *service) Watch(req *rpc.WatchRequest, srv rpc.V1_WatchServer) error {
+	return s.b.Subscribe(srv.Context(), req.Filter, func(ev *backend.Event) error {
+		return srv.Send(convertEvent(ev))
+	})
+}
+
+func convertEvent(ev *backend.Event) *rpc.Event {
+	out := &rpc.Event{
+		Type:      convertEventType(ev.Type),
+		Name:      ev.Name,
+		Namespace: ev.Namespace,
+	}
+	if ev.Handle != nil {
+		out.Handle = convertHandle(ev.Handle)
+	}
+	return out
+}
+
+func convertEventType(t backend.EventType) rpc.Event_Type {
+	switch t {
+	case backend.EventAcquired:
+		return rpc.Event_ACQUIRED
+	case backend.EventRenewed:
+		return rpc.Event_RENEWED
+	case backend.EventDone:
+		return rpc.Event_DONE
+	case backend.EventExpired:
+		return rpc.Event_EXPIRED
+	default:
+		return rpc.Event_ACQUIRED
+	}
+}
+
 func convertHandle(data *backend.HandleData) *rpc.Handle {
 	expMillis := data.ExpTime.Unix()*1000 + int64(data.ExpTime.Nanosecond())/1e6
 	return &rpc.Handle{
@@ -95,6 +173,7 @@ func convertHandle(data *backend.HandleData) *rpc.Handle {
 		ExpTime:     expMillis,
 		NumAcquired: uint32(data.NumAcquired),
 		Metadata:    data.Metadata,
+		Fence:       data.Fence,
 	}
 }
 
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsm/accord/backend"
+	"github.com/bsm/accord/rpc"
+	"github.com/google/uuid"
+)
+
+func TestConvertHandle(t *testing.T) {
+	id := uuid.New()
+	expTime := time.Now().Add(time.Minute).Truncate(time.Millisecond)
+
+	out := convertHandle(&backend.HandleData{
+		ID:          id,
+		Name:        "res",
+		Namespace:   "ns",
+		ExpTime:     expTime,
+		NumAcquired: 2,
+		Metadata:    map[string]string{"k": "v"},
+		Fence:       42,
+	})
+
+	if out.Name != "res" || out.Namespace != "ns" || out.NumAcquired != 2 {
+		t.Fatalf("convertHandle() = %+v, want Name=res, Namespace=ns, NumAcquired=2", out)
+	}
+	if out.Fence != 42 {
+		t.Fatalf("convertHandle() Fence = %d, want 42", out.Fence)
+	}
+	wantMillis := expTime.Unix()*1000 + int64(expTime.Nanosecond())/1e6
+	if out.ExpTime != wantMillis {
+		t.Fatalf("convertHandle() ExpTime = %d, want %d", out.ExpTime, wantMillis)
+	}
+	if gotID, err := uuid.FromBytes(out.Id); err != nil || gotID != id {
+		t.Fatalf("convertHandle() Id = %v (err %v), want %v", out.Id, err, id)
+	}
+}
+
+func TestConvertEventType(t *testing.T) {
+	cases := []struct {
+		in   backend.EventType
+		want rpc.Event_Type
+	}{
+		{backend.EventAcquired, rpc.Event_ACQUIRED},
+		{backend.EventRenewed, rpc.Event_RENEWED},
+		{backend.EventDone, rpc.Event_DONE},
+		{backend.EventExpired, rpc.Event_EXPIRED},
+	}
+	for _, c := range cases {
+		if got := convertEventType(c.in); got != c.want {
+			t.Errorf("convertEventType(%v) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestConvertEventCarriesFence(t *testing.T) {
+	out := convertEvent(&backend.Event{
+		Type:      backend.EventAcquired,
+		Namespace: "ns",
+		Name:      "res",
+		Handle:    &backend.HandleData{Fence: 7},
+	})
+	if out.Handle == nil || out.Handle.Fence != 7 {
+		t.Fatalf("convertEvent() Handle = %+v, want Fence=7", out.Handle)
+	}
+}
+
+func TestConvertEventExpiredHasNoHandle(t *testing.T) {
+	out := convertEvent(&backend.Event{Type: backend.EventExpired, Namespace: "ns", Name: "res"})
+	if out.Handle != nil {
+		t.Fatalf("convertEvent() Handle = %+v, want nil for EventExpired", out.Handle)
+	}
+}
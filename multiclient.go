@@ -0,0 +1,239 @@
+package accord
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bsm/accord/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// DialClients maintains connections to several accord servers and
+// transparently fails over between them, using the standard gRPC
+// health checking protocol to steer Acquire/Renew/Done calls away from
+// unhealthy endpoints. This allows running accord in an active/standby
+// or replicated configuration without an external L4 load balancer.
+func DialClients(ctx context.Context, targets []string, opt *ClientOptions, dialOpt ...grpc.DialOption) (Client, error) {
+	if len(targets) == 0 {
+		return nil, errors.New("accord: no targets given")
+	}
+
+	m := newMultiRPC()
+	for _, target := range targets {
+		cc, err := grpc.DialContext(ctx, target, dialOpt...)
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+		m.add(cc)
+	}
+
+	ci, err := RPCClient(ctx, m, opt)
+	if err != nil {
+		m.Close()
+		return nil, err
+	}
+
+	ci.(*client).closeFn = m.Close
+	return ci, nil
+}
+
+// endpoint wraps a single accord server connection along with its
+// health-checking status, refreshed in the background.
+type endpoint struct {
+	cc      *grpc.ClientConn
+	v1      proto.V1Client
+	health  healthpb.HealthClient
+	healthy atomic.Bool
+}
+
+func (e *endpoint) watchHealth(ctx context.Context) {
+	e.healthy.Store(true)
+
+	t := time.NewTicker(5 * time.Second)
+	defer t.Stop()
+
+	for {
+		res, err := e.health.Check(ctx, &healthpb.HealthCheckRequest{})
+		e.healthy.Store(err == nil && res.Status == healthpb.HealthCheckResponse_SERVING)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// multiRPC implements proto.V1Client by fanning calls out across a set
+// of endpoints, retrying Unavailable errors against the next endpoint
+// with a jittered exponential backoff.
+type multiRPC struct {
+	eps    []*endpoint
+	cur    uint32
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+}
+
+func newMultiRPC() *multiRPC {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &multiRPC{ctx: ctx, cancel: cancel}
+}
+
+func (m *multiRPC) add(cc *grpc.ClientConn) {
+	ep := &endpoint{
+		cc:     cc,
+		v1:     proto.NewV1Client(cc),
+		health: healthpb.NewHealthClient(cc),
+	}
+
+	m.mu.Lock()
+	m.eps = append(m.eps, ep)
+	m.mu.Unlock()
+
+	go ep.watchHealth(m.ctx)
+}
+
+// next returns the next endpoint to try, round-robining among the
+// healthy ones and falling back to any endpoint if none are healthy.
+func (m *multiRPC) next() *endpoint {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	n := len(m.eps)
+	for i := 0; i < n; i++ {
+		ep := m.eps[int(atomic.AddUint32(&m.cur, 1)-1)%n]
+		if ep.healthy.Load() {
+			return ep
+		}
+	}
+	return m.eps[int(atomic.AddUint32(&m.cur, 1)-1)%n]
+}
+
+// call invokes fn against endpoints picked by next, retrying on
+// Unavailable for at most one attempt per configured endpoint. Since next
+// skips unhealthy endpoints, a healthy one may be retried while an
+// unhealthy one is never tried.
+func (m *multiRPC) call(ctx context.Context, fn func(proto.V1Client) error) error {
+	var lastErr error
+	for attempt, n := 0, len(m.eps); attempt < n; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff(attempt)):
+			}
+		}
+
+		err := fn(m.next().v1)
+		if err == nil {
+			return nil
+		}
+		if status.Code(err) != codes.Unavailable {
+			return err
+		}
+		lastErr = err
+	}
+	return lastErr
+}
+
+func backoff(attempt int) time.Duration {
+	base := 50 * time.Millisecond * time.Duration(int64(1)<<uint(attempt))
+	if base > 2*time.Second {
+		base = 2 * time.Second
+	}
+	return base/2 + time.Duration(rand.Int63n(int64(base/2)+1))
+}
+
+// Close closes every underlying connection and stops health checking.
+func (m *multiRPC) Close() error {
+	m.cancel()
+
+	var err error
+	for _, ep := range m.eps {
+		if e := ep.cc.Close(); e != nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// Acquire implements proto.V1Client.
+func (m *multiRPC) Acquire(ctx context.Context, req *proto.AcquireRequest, opts ...grpc.CallOption) (*proto.AcquireResponse, error) {
+	var res *proto.AcquireResponse
+	err := m.call(ctx, func(cli proto.V1Client) error {
+		r, err := cli.Acquire(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+		res = r
+		return nil
+	})
+	return res, err
+}
+
+// Renew implements proto.V1Client.
+func (m *multiRPC) Renew(ctx context.Context, req *proto.RenewRequest, opts ...grpc.CallOption) (*proto.RenewResponse, error) {
+	var res *proto.RenewResponse
+	err := m.call(ctx, func(cli proto.V1Client) error {
+		r, err := cli.Renew(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+		res = r
+		return nil
+	})
+	return res, err
+}
+
+// Done implements proto.V1Client.
+func (m *multiRPC) Done(ctx context.Context, req *proto.DoneRequest, opts ...grpc.CallOption) (*proto.DoneResponse, error) {
+	var res *proto.DoneResponse
+	err := m.call(ctx, func(cli proto.V1Client) error {
+		r, err := cli.Done(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+		res = r
+		return nil
+	})
+	return res, err
+}
+
+// List implements proto.V1Client. Streaming calls aren't retried
+// mid-stream; a failed dial is attempted against the next endpoint.
+func (m *multiRPC) List(ctx context.Context, req *proto.ListRequest, opts ...grpc.CallOption) (proto.V1_ListClient, error) {
+	var res proto.V1_ListClient
+	err := m.call(ctx, func(cli proto.V1Client) error {
+		r, err := cli.List(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+		res = r
+		return nil
+	})
+	return res, err
+}
+
+// Watch implements proto.V1Client. Streaming calls aren't retried
+// mid-stream; a failed dial is attempted against the next endpoint.
+func (m *multiRPC) Watch(ctx context.Context, req *proto.WatchRequest, opts ...grpc.CallOption) (proto.V1_WatchClient, error) {
+	var res proto.V1_WatchClient
+	err := m.call(ctx, func(cli proto.V1Client) error {
+		r, err := cli.Watch(ctx, req, opts...)
+		if err != nil {
+			return err
+		}
+		res = r
+		return nil
+	})
+	return res, err
+}
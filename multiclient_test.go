@@ -0,0 +1,122 @@
+package accord
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsm/accord/internal/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeV1Client is a minimal proto.V1Client whose Acquire calls are
+// scripted by a queue of canned errors, used to exercise multiRPC's
+// retry-and-failover logic without a real server.
+type fakeV1Client struct {
+	acquireErrs []error
+	calls       int
+}
+
+func (f *fakeV1Client) Acquire(ctx context.Context, req *proto.AcquireRequest, opts ...grpc.CallOption) (*proto.AcquireResponse, error) {
+	i := f.calls
+	f.calls++
+	if i < len(f.acquireErrs) && f.acquireErrs[i] != nil {
+		return nil, f.acquireErrs[i]
+	}
+	return &proto.AcquireResponse{}, nil
+}
+
+func (f *fakeV1Client) Renew(ctx context.Context, req *proto.RenewRequest, opts ...grpc.CallOption) (*proto.RenewResponse, error) {
+	return &proto.RenewResponse{}, nil
+}
+
+func (f *fakeV1Client) Done(ctx context.Context, req *proto.DoneRequest, opts ...grpc.CallOption) (*proto.DoneResponse, error) {
+	return &proto.DoneResponse{}, nil
+}
+
+func (f *fakeV1Client) List(ctx context.Context, req *proto.ListRequest, opts ...grpc.CallOption) (proto.V1_ListClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (f *fakeV1Client) Watch(ctx context.Context, req *proto.WatchRequest, opts ...grpc.CallOption) (proto.V1_WatchClient, error) {
+	return nil, errors.New("not implemented")
+}
+
+func newTestEndpoint(cli proto.V1Client, healthy bool) *endpoint {
+	ep := &endpoint{v1: cli}
+	ep.healthy.Store(healthy)
+	return ep
+}
+
+func TestMultiRPCNextPrefersHealthy(t *testing.T) {
+	down := newTestEndpoint(&fakeV1Client{}, false)
+	up := newTestEndpoint(&fakeV1Client{}, true)
+	m := &multiRPC{eps: []*endpoint{down, up}}
+
+	for i := 0; i < 10; i++ {
+		if got := m.next(); got != up {
+			t.Fatalf("next() = %p, want the healthy endpoint %p", got, up)
+		}
+	}
+}
+
+func TestMultiRPCNextFallsBackWhenAllUnhealthy(t *testing.T) {
+	a := newTestEndpoint(&fakeV1Client{}, false)
+	b := newTestEndpoint(&fakeV1Client{}, false)
+	m := &multiRPC{eps: []*endpoint{a, b}}
+
+	if got := m.next(); got != a && got != b {
+		t.Fatalf("next() = %p, want one of the configured endpoints", got)
+	}
+}
+
+func TestMultiRPCCallFailsOverOnUnavailable(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "down")
+	m := &multiRPC{eps: []*endpoint{
+		newTestEndpoint(&fakeV1Client{acquireErrs: []error{unavailable}}, true),
+		newTestEndpoint(&fakeV1Client{acquireErrs: []error{unavailable}}, true),
+		newTestEndpoint(&fakeV1Client{}, true),
+	}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if _, err := m.Acquire(ctx, &proto.AcquireRequest{}); err != nil {
+		t.Fatalf("Acquire() error = %v, want nil after failing over to a healthy endpoint", err)
+	}
+}
+
+func TestMultiRPCCallStopsOnNonUnavailableError(t *testing.T) {
+	wantErr := status.Error(codes.InvalidArgument, "bad request")
+	m := &multiRPC{eps: []*endpoint{
+		newTestEndpoint(&fakeV1Client{acquireErrs: []error{wantErr}}, true),
+		newTestEndpoint(&fakeV1Client{}, true),
+	}}
+
+	_, err := m.Acquire(context.Background(), &proto.AcquireRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Acquire() error = %v, want InvalidArgument without trying the next endpoint", err)
+	}
+}
+
+func TestBackoffGrowsAndCaps(t *testing.T) {
+	for attempt := 1; attempt < 10; attempt++ {
+		d := backoff(attempt)
+		if d < 0 {
+			t.Fatalf("backoff(%d) = %v, want >= 0", attempt, d)
+		}
+		if d > 2*time.Second {
+			t.Fatalf("backoff(%d) = %v, want <= 2s cap", attempt, d)
+		}
+		floor := (50 * time.Millisecond << uint(attempt)) / 2
+		if floor > time.Second {
+			floor = time.Second
+		}
+		if d < floor {
+			t.Fatalf("backoff(%d) = %v, want >= %v", attempt, d, floor)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package accord
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/bsm/accord/internal/proto"
+)
+
+// EventType identifies the kind of change an Event describes.
+type EventType int
+
+// Event types reported by Watch.
+const (
+	EventAcquired EventType = iota
+	EventRenewed
+	EventDone
+	EventExpired
+)
+
+// Event describes a state change for a single handle, as reported by
+// Watch.
+type Event struct {
+	Type      EventType
+	Namespace string
+	Name      string
+	Metadata  map[string]string
+}
+
+// WatchFilter restricts the handles a Watch call reports events for.
+type WatchFilter struct {
+	// Namespace restricts events to handles in this namespace, default: "" (all)
+	Namespace string
+}
+
+// Watch implements Client interface.
+func (c *client) Watch(ctx context.Context, filter *WatchFilter) (<-chan *Event, error) {
+	var pf proto.ListRequest_Filter
+	if filter != nil {
+		pf.Prefix = filter.Namespace
+	}
+
+	stream, err := c.rpc.Watch(ctx, &proto.WatchRequest{Filter: &pf})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan *Event)
+	go func() {
+		defer close(out)
+		for {
+			ev, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF {
+					c.opt.handleError(err)
+				}
+				return
+			}
+			if filter != nil && filter.Namespace != "" && ev.Namespace != filter.Namespace {
+				continue
+			}
+
+			select {
+			case out <- convertEvent(ev):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func convertEvent(ev *proto.Event) *Event {
+	out := &Event{
+		Type:      convertEventType(ev.Type),
+		Namespace: ev.Namespace,
+		Name:      ev.Name,
+	}
+	if ev.Handle != nil {
+		out.Metadata = ev.Handle.Metadata
+	}
+	return out
+}
+
+func convertEventType(t proto.Event_Type) EventType {
+	switch t {
+	case proto.Event_RENEWED:
+		return EventRenewed
+	case proto.Event_DONE:
+		return EventDone
+	case proto.Event_EXPIRED:
+		return EventExpired
+	default:
+		return EventAcquired
+	}
+}
+
+// watchDone keeps the local done cache primed with a live subscription,
+// reconnecting with a short backoff if the stream drops. It follows the
+// one-shot fetchDone at startup with an ongoing feed, so Acquire can
+// short-circuit sooner across a fleet of workers sharing a namespace.
+func (c *client) watchDone(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := c.watchDoneOnce(ctx); err != nil && ctx.Err() == nil {
+			c.opt.handleError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (c *client) watchDoneOnce(ctx context.Context) error {
+	stream, err := c.rpc.Watch(ctx, &proto.WatchRequest{
+		Filter: &proto.ListRequest_Filter{
+			Prefix: c.opt.Namespace,
+			Status: proto.ListRequest_Filter_DONE,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		ev, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if ev.Type == proto.Event_DONE && ev.Namespace == c.opt.Namespace {
+			if err := c.cache.Add(ev.Name); err != nil {
+				return err
+			}
+		}
+	}
+}
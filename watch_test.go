@@ -0,0 +1,48 @@
+package accord
+
+import (
+	"testing"
+
+	"github.com/bsm/accord/internal/proto"
+)
+
+func TestConvertEventType(t *testing.T) {
+	cases := []struct {
+		in   proto.Event_Type
+		want EventType
+	}{
+		{proto.Event_ACQUIRED, EventAcquired},
+		{proto.Event_RENEWED, EventRenewed},
+		{proto.Event_DONE, EventDone},
+		{proto.Event_EXPIRED, EventExpired},
+	}
+	for _, c := range cases {
+		if got := convertEventType(c.in); got != c.want {
+			t.Errorf("convertEventType(%v) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestConvertEvent(t *testing.T) {
+	ev := &proto.Event{
+		Type:      proto.Event_RENEWED,
+		Namespace: "ns",
+		Name:      "res",
+		Handle:    &proto.Handle{Metadata: map[string]string{"k": "v"}},
+	}
+
+	out := convertEvent(ev)
+	if out.Type != EventRenewed || out.Namespace != "ns" || out.Name != "res" {
+		t.Fatalf("convertEvent() = %+v, want Type=EventRenewed, Namespace=ns, Name=res", out)
+	}
+	if out.Metadata["k"] != "v" {
+		t.Fatalf("convertEvent() Metadata = %+v, want {k: v}", out.Metadata)
+	}
+}
+
+func TestConvertEventNilHandle(t *testing.T) {
+	out := convertEvent(&proto.Event{Type: proto.Event_EXPIRED, Namespace: "ns", Name: "res"})
+	if out.Metadata != nil {
+		t.Fatalf("convertEvent() Metadata = %+v, want nil when Handle is nil", out.Metadata)
+	}
+}